@@ -0,0 +1,55 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttemptThreshold is the number of consecutive failed login
+// attempts, each within LoginAttemptWindow of the attempt before it, that
+// locks a user out until LockedUntil elapses.
+const LoginAttemptThreshold = 5
+
+// LoginAttemptWindow is the longest gap between two failed login attempts
+// for them to count toward the same streak. It is measured from the
+// previous attempt, not from the first failure of the streak, so failures
+// spaced just under this far apart still reach LoginAttemptThreshold.
+const LoginAttemptWindow = 15 * time.Minute
+
+// LoginLockoutDuration is how long a user remains locked out once
+// RecordLogin sets LockedUntil.
+const LoginLockoutDuration = 15 * time.Minute
+
+// ErrUserLocked is returned by Authenticate when the user's LockedUntil
+// has not yet elapsed.
+var ErrUserLocked = &Error{
+	Code: ELocked,
+	Msg:  "account is temporarily locked due to repeated failed login attempts",
+}
+
+// LoginEvent is a single recorded login attempt, successful or not.
+type LoginEvent struct {
+	UserID    ID        `json:"userID"`
+	Time      time.Time `json:"time"`
+	IP        string    `json:"ip"`
+	Succeeded bool      `json:"succeeded"`
+}
+
+// TimeRange bounds a query to events between Start and Stop, inclusive.
+type TimeRange struct {
+	Start time.Time
+	Stop  time.Time
+}
+
+// UserAuditService exposes the append-only history of login attempts
+// recorded by UserService.RecordLogin.
+type UserAuditService interface {
+	// RecordLoginEvent appends evt to the log. It is called by
+	// UserService.RecordLogin and should not be called directly by other
+	// callers.
+	RecordLoginEvent(ctx context.Context, evt LoginEvent) error
+
+	// FindLoginEvents returns the login events for a user within tr,
+	// ordered oldest first.
+	FindLoginEvents(ctx context.Context, id ID, tr TimeRange) ([]LoginEvent, error)
+}
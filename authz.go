@@ -0,0 +1,145 @@
+package influxdb
+
+import "context"
+
+// SYSTEM_ADMIN is the bootstrap role that
+// MigrateAssignSystemAdminToExistingUsers grants to every user holding no
+// role, such as users migrated from a version of InfluxDB that predates
+// roles. Newly created users are not granted it automatically. It carries
+// every permission.
+const SYSTEM_ADMIN = "SYSTEM_ADMIN"
+
+// Well-known permissions consulted by UserService. Other services define
+// their own permissions following the "action:resource" convention.
+const (
+	PermissionReadOrgs     = "read:orgs"
+	PermissionWriteOrgs    = "write:orgs"
+	PermissionReadBuckets  = "read:buckets"
+	PermissionWriteBuckets = "write:buckets"
+	PermissionReadUsers    = "read:users"
+	PermissionWriteUsers   = "write:users"
+)
+
+// Role is a named set of permission grants that can be assigned to users.
+type Role struct {
+	ID          ID       `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleFilter represents a set of filters that restrict the roles returned
+// by FindRoles.
+type RoleFilter struct {
+	ID   *ID
+	Name *string
+}
+
+// RoleService represents a service for managing roles and the permissions
+// granted to them.
+type RoleService interface {
+	// Returns a single role by ID.
+	FindRoleByID(ctx context.Context, id ID) (*Role, error)
+
+	// Returns a list of roles that match filter.
+	FindRoles(ctx context.Context, filter RoleFilter) ([]*Role, error)
+
+	// Creates a new role and sets r.ID with the new identifier.
+	CreateRole(ctx context.Context, r *Role) error
+
+	// Replaces the permission set of an existing role.
+	SetRolePermissions(ctx context.Context, id ID, permissions []string) error
+
+	// Removes a role by ID.
+	DeleteRole(ctx context.Context, id ID) error
+}
+
+// Principal identifies the authenticated caller of a request, resolved by
+// the HTTP middleware from an incoming token before the request reaches a
+// service method.
+type Principal struct {
+	UserID ID
+	Roles  []string
+}
+
+// principalContextKey is unexported so only this package can set or read
+// the Principal stored on a context.
+type principalContextKey struct{}
+
+// ContextWithPrincipal returns a new context carrying p, retrievable with
+// PrincipalFromContext.
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext returns the Principal stored on ctx by the HTTP
+// middleware, and false if ctx carries none.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return p, ok
+}
+
+// Authorizer resolves the effective permissions of a Principal from its
+// roles and checks whether a given permission is granted.
+type Authorizer struct {
+	roles RoleService
+}
+
+// NewAuthorizer returns an Authorizer that resolves permissions via roles.
+func NewAuthorizer(roles RoleService) *Authorizer {
+	return &Authorizer{roles: roles}
+}
+
+// Permissions returns the full set of permissions granted to p across all
+// of its roles. A SYSTEM_ADMIN role grants every permission known to roles.
+func (a *Authorizer) Permissions(ctx context.Context, p *Principal) (map[string]bool, error) {
+	perms := make(map[string]bool)
+	for _, name := range p.Roles {
+		if name == SYSTEM_ADMIN {
+			all, err := a.roles.FindRoles(ctx, RoleFilter{})
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range all {
+				for _, perm := range r.Permissions {
+					perms[perm] = true
+				}
+			}
+			continue
+		}
+
+		rs, err := a.roles.FindRoles(ctx, RoleFilter{Name: &name})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			for _, perm := range r.Permissions {
+				perms[perm] = true
+			}
+		}
+	}
+	return perms, nil
+}
+
+// Authorize returns EForbidden if ctx's Principal does not hold permission.
+// A missing Principal is always unauthorized.
+func (a *Authorizer) Authorize(ctx context.Context, permission string) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return &Error{Code: EForbidden, Msg: "no authenticated principal in context"}
+	}
+
+	for _, name := range p.Roles {
+		if name == SYSTEM_ADMIN {
+			return nil
+		}
+	}
+
+	perms, err := a.Permissions(ctx, p)
+	if err != nil {
+		return err
+	}
+	if !perms[permission] {
+		return &Error{Code: EForbidden, Msg: "insufficient permissions to perform this action"}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	platform "github.com/influxdata/influxdb/v2"
+	bolt "go.etcd.io/bbolt"
+)
+
+var loginEventBucket = []byte("logineventsv1")
+
+// UserAuditService is a platform.UserAuditService backed by a single
+// BoltDB file. Events are keyed by the user's ID followed by its
+// UnixNano timestamp, so FindLoginEvents can satisfy its oldest-first
+// contract with a single prefix scan in Bolt's natural byte-sorted key
+// order, without a secondary index.
+type UserAuditService struct {
+	db *bolt.DB
+}
+
+// NewUserAuditService returns a UserAuditService backed by db, creating
+// its bucket if it doesn't already exist.
+func NewUserAuditService(ctx context.Context, db *bolt.DB) (*UserAuditService, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(loginEventBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &UserAuditService{db: db}, nil
+}
+
+func loginEventKey(id platform.ID, t time.Time) ([]byte, error) {
+	idBytes, err := id.Encode()
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, len(idBytes)+8)
+	copy(key, idBytes)
+	binary.BigEndian.PutUint64(key[len(idBytes):], uint64(t.UnixNano()))
+	return key, nil
+}
+
+func (s *UserAuditService) RecordLoginEvent(ctx context.Context, evt platform.LoginEvent) error {
+	key, err := loginEventKey(evt.UserID, evt.Time)
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(loginEventBucket).Put(key, v)
+	})
+}
+
+func (s *UserAuditService) FindLoginEvents(ctx context.Context, id platform.ID, tr platform.TimeRange) ([]platform.LoginEvent, error) {
+	idBytes, err := id.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []platform.LoginEvent
+	err = s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(loginEventBucket).Cursor()
+		for k, v := c.Seek(idBytes); k != nil && bytes.HasPrefix(k, idBytes); k, v = c.Next() {
+			var evt platform.LoginEvent
+			if err := json.Unmarshal(v, &evt); err != nil {
+				return err
+			}
+			if evt.Time.Before(tr.Start) || evt.Time.After(tr.Stop) {
+				continue
+			}
+			events = append(events, evt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,285 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	platform "github.com/influxdata/influxdb/v2"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	userBucket           = []byte("usersv1")
+	userIndexBucket      = []byte("userindexv1")
+	userLastUpdateBucket = []byte("userlastupdatev1")
+)
+
+// UserStorageBackend is a platform.UserStorageBackend backed by a single
+// BoltDB file. Users are stored as JSON, keyed by their ID; userIndexBucket
+// maps a lowercased user name to that ID so name-based lookups
+// (LookupUserID, UserExists, Authenticate) don't require a full bucket
+// scan.
+type UserStorageBackend struct {
+	db *bolt.DB
+}
+
+// NewUserStorageBackend returns a UserStorageBackend backed by db,
+// creating its buckets if they don't already exist.
+func NewUserStorageBackend(ctx context.Context, db *bolt.DB) (*UserStorageBackend, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(userBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(userIndexBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(userLastUpdateBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &UserStorageBackend{db: db}, nil
+}
+
+func indexKey(name string) []byte {
+	return []byte(strings.ToLower(name))
+}
+
+func putLastUpdate(tx *bolt.Tx, idBytes []byte) error {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(time.Now().UnixNano()))
+	return tx.Bucket(userLastUpdateBucket).Put(idBytes, buf)
+}
+
+func (s *UserStorageBackend) GetBy(key interface{}) (*platform.User, error) {
+	var u platform.User
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var id platform.ID
+
+		switch k := key.(type) {
+		case platform.ID:
+			id = k
+		case string:
+			b := tx.Bucket(userIndexBucket).Get(indexKey(k))
+			if b == nil {
+				return &platform.Error{Code: platform.ENotFound, Msg: "user not found"}
+			}
+			if err := id.Decode(b); err != nil {
+				return err
+			}
+		default:
+			return &platform.Error{Code: platform.EInvalid, Msg: "GetBy key must be an ID or a name"}
+		}
+
+		idBytes, err := id.Encode()
+		if err != nil {
+			return err
+		}
+		v := tx.Bucket(userBucket).Get(idBytes)
+		if v == nil {
+			return &platform.Error{Code: platform.ENotFound, Msg: "user not found"}
+		}
+		return json.Unmarshal(v, &u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *UserStorageBackend) Gets(filter platform.UserFilter, opt platform.FindOptions) ([]*platform.User, int, error) {
+	var users []*platform.User
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(userBucket).ForEach(func(k, v []byte) error {
+			var u platform.User
+			if err := json.Unmarshal(v, &u); err != nil {
+				return err
+			}
+
+			if filter.ID != nil && *filter.ID != u.ID {
+				return nil
+			}
+			if filter.Name != nil && !strings.EqualFold(*filter.Name, u.Name) {
+				return nil
+			}
+			if filter.Role != nil && !containsRole(u.Roles, *filter.Role) {
+				return nil
+			}
+			if filter.LastLoginBefore != nil && !u.LastLoginAt.Before(*filter.LastLoginBefore) {
+				return nil
+			}
+
+			users = append(users, &u)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n := len(users)
+	sortUsers(users, opt)
+	return paginateUsers(users, opt), n, nil
+}
+
+// sortUsers orders users according to opt.SortBy/opt.Descending, defaulting
+// to a case-insensitive name sort, matching the ordering the in-memory
+// backend uses so callers see consistent results across backends.
+func sortUsers(users []*platform.User, opt platform.FindOptions) {
+	sort.Slice(users, func(i, j int) bool {
+		var less bool
+		switch opt.SortBy {
+		case "ID":
+			less = users[i].ID < users[j].ID
+		case "LastLoginAt":
+			less = users[i].LastLoginAt.Before(users[j].LastLoginAt)
+		default:
+			less = strings.ToLower(users[i].Name) < strings.ToLower(users[j].Name)
+		}
+		if opt.Descending {
+			return !less
+		}
+		return less
+	})
+}
+
+// paginateUsers applies opt.Offset/opt.Limit to an already-sorted slice.
+func paginateUsers(users []*platform.User, opt platform.FindOptions) []*platform.User {
+	if opt.Offset > 0 {
+		if opt.Offset >= len(users) {
+			return nil
+		}
+		users = users[opt.Offset:]
+	}
+	if opt.Limit > 0 && opt.Limit < len(users) {
+		users = users[:opt.Limit]
+	}
+	return users
+}
+
+func (s *UserStorageBackend) Save(u *platform.User) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		idxKey := indexKey(u.Name)
+		if existing := tx.Bucket(userIndexBucket).Get(idxKey); existing != nil {
+			return &platform.Error{Code: platform.EConflict, Msg: "user with that name already exists"}
+		}
+
+		idBytes, err := u.ID.Encode()
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(userBucket).Put(idBytes, v); err != nil {
+			return err
+		}
+		if err := tx.Bucket(userIndexBucket).Put(idxKey, idBytes); err != nil {
+			return err
+		}
+		return putLastUpdate(tx, idBytes)
+	})
+}
+
+// Update persists u's current state, keeping the name index in sync if
+// u's name differs, case-insensitively, from the stored one. fields is accepted for interface
+// compatibility with platform.UserStorageBackend: Bolt has no notion of a
+// partial record write, so the whole value is always rewritten.
+func (s *UserStorageBackend) Update(u *platform.User, fields ...string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		idBytes, err := u.ID.Encode()
+		if err != nil {
+			return err
+		}
+
+		existing := tx.Bucket(userBucket).Get(idBytes)
+		if existing == nil {
+			return &platform.Error{Code: platform.ENotFound, Msg: "user not found"}
+		}
+		var old platform.User
+		if err := json.Unmarshal(existing, &old); err != nil {
+			return err
+		}
+
+		if !strings.EqualFold(old.Name, u.Name) {
+			if other := tx.Bucket(userIndexBucket).Get(indexKey(u.Name)); other != nil {
+				return &platform.Error{Code: platform.EConflict, Msg: "user with that name already exists"}
+			}
+			if err := tx.Bucket(userIndexBucket).Delete(indexKey(old.Name)); err != nil {
+				return err
+			}
+			if err := tx.Bucket(userIndexBucket).Put(indexKey(u.Name), idBytes); err != nil {
+				return err
+			}
+		}
+
+		v, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(userBucket).Put(idBytes, v); err != nil {
+			return err
+		}
+		return putLastUpdate(tx, idBytes)
+	})
+}
+
+func (s *UserStorageBackend) DeleteByID(id platform.ID) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		idBytes, err := id.Encode()
+		if err != nil {
+			return err
+		}
+
+		v := tx.Bucket(userBucket).Get(idBytes)
+		if v == nil {
+			return &platform.Error{Code: platform.ENotFound, Msg: "user not found"}
+		}
+		var u platform.User
+		if err := json.Unmarshal(v, &u); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(userIndexBucket).Delete(indexKey(u.Name)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(userLastUpdateBucket).Delete(idBytes); err != nil {
+			return err
+		}
+		return tx.Bucket(userBucket).Delete(idBytes)
+	})
+}
+
+func (s *UserStorageBackend) LastUpdate(id platform.ID) int64 {
+	var last int64
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		idBytes, err := id.Encode()
+		if err != nil {
+			return err
+		}
+		v := tx.Bucket(userLastUpdateBucket).Get(idBytes)
+		if v == nil {
+			return nil
+		}
+		last = int64(binary.BigEndian.Uint64(v))
+		return nil
+	})
+	return last
+}
+
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
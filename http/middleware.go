@@ -0,0 +1,53 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	platform "github.com/influxdata/influxdb/v2"
+)
+
+// TokenLookupService resolves a bearer token to the principal it
+// authenticates. It is implemented by the authentication/session service;
+// kept minimal here so this middleware doesn't need to know how tokens
+// are issued or stored.
+type TokenLookupService interface {
+	// FindPrincipalByToken returns the Principal a token authenticates,
+	// or EForbidden if the token is missing, malformed, or unknown.
+	FindPrincipalByToken(ctx context.Context, token string) (*platform.Principal, error)
+}
+
+// WithPrincipal returns middleware that resolves the Authorization
+// header's bearer token to a platform.Principal via tokens, and stores it
+// on the request context with platform.ContextWithPrincipal so downstream
+// UserService calls can authorize against it. Requests with no or invalid
+// token are passed through without a Principal; service methods that
+// require one (see Authorizer.Authorize) reject them with EForbidden.
+func WithPrincipal(tokens TokenLookupService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := tokens.FindPrincipalByToken(r.Context(), token)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := platform.ContextWithPrincipal(r.Context(), p)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(h, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(h, prefix)
+}
@@ -0,0 +1,43 @@
+package influxdb
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// inmemUserAuditService is an append-only, in-memory UserAuditService. It
+// backs tests the same way inmemUserStorageBackend backs UserService: the
+// durable implementation is bolt.UserAuditService.
+type inmemUserAuditService struct {
+	mu     sync.Mutex
+	events map[ID][]LoginEvent
+}
+
+// NewInmemUserAuditService returns an empty, in-memory UserAuditService.
+func NewInmemUserAuditService() UserAuditService {
+	return &inmemUserAuditService{events: make(map[ID][]LoginEvent)}
+}
+
+func (s *inmemUserAuditService) RecordLoginEvent(ctx context.Context, evt LoginEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[evt.UserID] = append(s.events[evt.UserID], evt)
+	return nil
+}
+
+func (s *inmemUserAuditService) FindLoginEvents(ctx context.Context, id ID, tr TimeRange) ([]LoginEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []LoginEvent
+	for _, evt := range s.events[id] {
+		if evt.Time.Before(tr.Start) || evt.Time.After(tr.Stop) {
+			continue
+		}
+		matched = append(matched, evt)
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Time.Before(matched[j].Time) })
+	return matched, nil
+}
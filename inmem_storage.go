@@ -0,0 +1,186 @@
+package influxdb
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// inmemUserStorageBackend is a UserStorageBackend held entirely in
+// memory. It exists for tests that need a UserService without standing
+// up BoltDB or Postgres.
+type inmemUserStorageBackend struct {
+	mu sync.Mutex
+
+	byID map[ID]*User
+	// byName is the name -> ID secondary index, keyed by lowercased
+	// name, so GetBy(name)/LookupUserID/UserExists are O(1) instead of a
+	// scan over every user.
+	byName     map[string]ID
+	lastUpdate map[ID]int64
+}
+
+// NewInmemUserStorageBackend returns an empty, in-memory
+// UserStorageBackend.
+func NewInmemUserStorageBackend() UserStorageBackend {
+	return &inmemUserStorageBackend{
+		byID:       make(map[ID]*User),
+		byName:     make(map[string]ID),
+		lastUpdate: make(map[ID]int64),
+	}
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(name)
+}
+
+func (b *inmemUserStorageBackend) GetBy(key interface{}) (*User, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch k := key.(type) {
+	case ID:
+		if u, ok := b.byID[k]; ok {
+			cp := *u
+			return &cp, nil
+		}
+	case string:
+		if id, ok := b.byName[nameKey(k)]; ok {
+			cp := *b.byID[id]
+			return &cp, nil
+		}
+	}
+
+	return nil, &Error{Code: ENotFound, Msg: "user not found"}
+}
+
+func (b *inmemUserStorageBackend) Gets(filter UserFilter, opt FindOptions) ([]*User, int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var matched []*User
+	for _, u := range b.byID {
+		if filter.ID != nil && *filter.ID != u.ID {
+			continue
+		}
+		if filter.Name != nil && !strings.EqualFold(*filter.Name, u.Name) {
+			continue
+		}
+		if filter.Role != nil && !containsString(u.Roles, *filter.Role) {
+			continue
+		}
+		if filter.LastLoginBefore != nil && !u.LastLoginAt.Before(*filter.LastLoginBefore) {
+			continue
+		}
+		cp := *u
+		matched = append(matched, &cp)
+	}
+
+	n := len(matched)
+	sortUsers(matched, opt)
+	return paginateUsers(matched, opt), n, nil
+}
+
+// sortUsers orders users according to opt.SortBy/opt.Descending, defaulting
+// to a case-insensitive name sort. Kept in sync with bolt.sortUsers so
+// FindUsers behaves the same regardless of backend.
+func sortUsers(users []*User, opt FindOptions) {
+	sort.Slice(users, func(i, j int) bool {
+		var less bool
+		switch opt.SortBy {
+		case "ID":
+			less = users[i].ID < users[j].ID
+		case "LastLoginAt":
+			less = users[i].LastLoginAt.Before(users[j].LastLoginAt)
+		default:
+			less = strings.ToLower(users[i].Name) < strings.ToLower(users[j].Name)
+		}
+		if opt.Descending {
+			return !less
+		}
+		return less
+	})
+}
+
+// paginateUsers applies opt.Offset/opt.Limit to an already-sorted slice.
+func paginateUsers(users []*User, opt FindOptions) []*User {
+	if opt.Offset > 0 {
+		if opt.Offset >= len(users) {
+			return nil
+		}
+		users = users[opt.Offset:]
+	}
+	if opt.Limit > 0 && opt.Limit < len(users) {
+		users = users[:opt.Limit]
+	}
+	return users
+}
+
+func (b *inmemUserStorageBackend) Save(u *User) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.byName[nameKey(u.Name)]; ok {
+		return &Error{Code: EConflict, Msg: "user with that name already exists"}
+	}
+
+	cp := *u
+	b.byID[u.ID] = &cp
+	b.byName[nameKey(u.Name)] = u.ID
+	b.lastUpdate[u.ID] = time.Now().UnixNano()
+	return nil
+}
+
+func (b *inmemUserStorageBackend) Update(u *User, fields ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	old, ok := b.byID[u.ID]
+	if !ok {
+		return &Error{Code: ENotFound, Msg: "user not found"}
+	}
+
+	if !strings.EqualFold(old.Name, u.Name) {
+		if existingID, ok := b.byName[nameKey(u.Name)]; ok && existingID != u.ID {
+			return &Error{Code: EConflict, Msg: "user with that name already exists"}
+		}
+		delete(b.byName, nameKey(old.Name))
+		b.byName[nameKey(u.Name)] = u.ID
+	}
+
+	cp := *u
+	b.byID[u.ID] = &cp
+	b.lastUpdate[u.ID] = time.Now().UnixNano()
+	return nil
+}
+
+func (b *inmemUserStorageBackend) DeleteByID(id ID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u, ok := b.byID[id]
+	if !ok {
+		return &Error{Code: ENotFound, Msg: "user not found"}
+	}
+
+	delete(b.byName, nameKey(u.Name))
+	delete(b.byID, id)
+	delete(b.lastUpdate, id)
+	return nil
+}
+
+func (b *inmemUserStorageBackend) LastUpdate(id ID) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastUpdate[id]
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package influxdb
+
+import "context"
+
+// MigrateAssignSystemAdminToExistingUsers grants the SYSTEM_ADMIN role to
+// every user that doesn't already hold a role. It is intended to run
+// once, immediately after upgrading a pre-RBAC installation: without it,
+// every existing user would suddenly lose access to every permission
+// check added in this release, since an empty Roles list satisfies none
+// of them.
+func MigrateAssignSystemAdminToExistingUsers(ctx context.Context, back UserStorageBackend) error {
+	users, _, err := back.Gets(UserFilter{}, FindOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if len(u.Roles) > 0 {
+			continue
+		}
+		u.Roles = []string{SYSTEM_ADMIN}
+		if err := back.Update(u, "Roles"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package influxdb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMigrateAssignSystemAdminToExistingUsers(t *testing.T) {
+	ctx := context.Background()
+	back := NewInmemUserStorageBackend()
+	svc := NewUserService(back)
+
+	noRoles := &User{Name: "marge", Status: "active"}
+	if err := svc.CreateUser(ctx, noRoles); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+
+	hasRole := &User{Name: "lisa", Status: "active", Roles: []string{"analyst"}}
+	if err := svc.CreateUser(ctx, hasRole); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+
+	if err := MigrateAssignSystemAdminToExistingUsers(ctx, back); err != nil {
+		t.Fatalf("MigrateAssignSystemAdminToExistingUsers() = %v", err)
+	}
+
+	got, err := svc.FindUserByID(ctx, noRoles.ID)
+	if err != nil {
+		t.Fatalf("FindUserByID() = %v", err)
+	}
+	if len(got.Roles) != 1 || got.Roles[0] != SYSTEM_ADMIN {
+		t.Fatalf("migrated user Roles = %v, want [%s]", got.Roles, SYSTEM_ADMIN)
+	}
+
+	got, err = svc.FindUserByID(ctx, hasRole.ID)
+	if err != nil {
+		t.Fatalf("FindUserByID() = %v", err)
+	}
+	if len(got.Roles) != 1 || got.Roles[0] != "analyst" {
+		t.Fatalf("already-roled user Roles = %v, want unchanged [analyst]", got.Roles)
+	}
+}
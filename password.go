@@ -0,0 +1,88 @@
+package influxdb
+
+import "golang.org/x/crypto/bcrypt"
+
+const (
+	// MinPasswordLength is the shortest password SetPassword will accept.
+	MinPasswordLength = 8
+
+	// DefaultPasswordHashCost is the bcrypt cost used when none is configured.
+	DefaultPasswordHashCost = bcrypt.DefaultCost
+)
+
+// ErrPasswordMismatch is returned by ComparePassword and Authenticate when
+// the supplied password does not match the stored hash. The same error is
+// used whether the user exists or not, so callers cannot use it to probe
+// for valid user names.
+var ErrPasswordMismatch = &Error{
+	Code: EForbidden,
+	Msg:  "your username or password is incorrect",
+}
+
+// ErrPasswordLength is returned when a candidate password is shorter than
+// MinPasswordLength.
+var ErrPasswordLength = &Error{
+	Code: EInvalid,
+	Msg:  "password is too short",
+}
+
+// ErrPasswordReused is returned when a candidate password hashes to the
+// same value as the user's current PasswordHash.
+var ErrPasswordReused = &Error{
+	Code: EInvalid,
+	Msg:  "password cannot be the same as your current password",
+}
+
+// PasswordHasher hashes and compares passwords. It is implemented by
+// bcryptHasher for production use and can be swapped out in tests for a
+// hasher with a lower, faster cost.
+type PasswordHasher interface {
+	// HashPassword returns the hash of password.
+	HashPassword(password string) (string, error)
+
+	// ComparePassword returns nil if hash is the hash of password, and
+	// ErrPasswordMismatch otherwise.
+	ComparePassword(hash, password string) error
+}
+
+// bcryptHasher is the default PasswordHasher, backed by golang.org/x/crypto/bcrypt.
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher returns a PasswordHasher using bcrypt at the given cost.
+// Lower costs (e.g. bcrypt.MinCost) are useful in tests that create many
+// users and don't need production-grade hashing work factors.
+func NewBcryptHasher(cost int) PasswordHasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) ComparePassword(hash, password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// checkPasswordValid validates a candidate password against the policy
+// enforced by SetPassword: a minimum length, and no reuse of the user's
+// current password.
+func checkPasswordValid(hasher PasswordHasher, currentHash, password string) error {
+	if len(password) < MinPasswordLength {
+		return ErrPasswordLength
+	}
+
+	if currentHash != "" && hasher.ComparePassword(currentHash, password) == nil {
+		return ErrPasswordReused
+	}
+
+	return nil
+}
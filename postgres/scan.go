@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	platform "github.com/influxdata/influxdb/v2"
+	"github.com/lib/pq"
+)
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows, letting
+// scanInto do the column mapping once for GetBy (a single row, via
+// scanUser) and Gets (many rows, via scanUserRows).
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row *sql.Row) (*platform.User, error) {
+	u, err := scanInto(row)
+	if err == sql.ErrNoRows {
+		return nil, &platform.Error{Code: platform.ENotFound, Msg: "user not found"}
+	}
+	return u, err
+}
+
+func scanUserRows(rows *sql.Rows) (*platform.User, error) {
+	return scanInto(rows)
+}
+
+func scanInto(row rowScanner) (*platform.User, error) {
+	var (
+		u                   platform.User
+		status              string
+		lastPasswordResetAt sql.NullTime
+		lastLoginIP         sql.NullString
+		lastLoginAt         sql.NullTime
+		lockedUntil         sql.NullTime
+		roles               pq.StringArray
+	)
+
+	err := row.Scan(&u.ID, &u.Name, &u.OAuthID, &status, &nullableString{&u.PasswordHash},
+		&u.PasswordResetRequired, &u.PasswordChangeForced, &lastPasswordResetAt, &roles,
+		&lastLoginIP, &lastLoginAt, &u.LastLoginAttempts, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Status = platform.Status(status)
+	u.Roles = []string(roles)
+	if lastPasswordResetAt.Valid {
+		u.LastPasswordResetAt = lastPasswordResetAt.Time
+	}
+	if lastLoginIP.Valid {
+		u.LastLoginIP = lastLoginIP.String
+	}
+	if lastLoginAt.Valid {
+		u.LastLoginAt = lastLoginAt.Time
+	}
+	if lockedUntil.Valid {
+		t := lockedUntil.Time
+		u.LockedUntil = &t
+	}
+
+	return &u, nil
+}
+
+// nullableString scans a nullable text column straight into a plain
+// string field, treating SQL NULL as "".
+type nullableString struct {
+	dest *string
+}
+
+func (n *nullableString) Scan(src interface{}) error {
+	if src == nil {
+		*n.dest = ""
+		return nil
+	}
+	switch v := src.(type) {
+	case string:
+		*n.dest = v
+	case []byte:
+		*n.dest = string(v)
+	default:
+		return fmt.Errorf("postgres: cannot scan %T into string", src)
+	}
+	return nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func nullTimePtr(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func pqStringArray(ss []string) pq.StringArray {
+	return pq.StringArray(ss)
+}
+
+func fmtArg(tmpl string, n int) string {
+	return fmt.Sprintf(tmpl, n)
+}
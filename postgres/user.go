@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"database/sql"
+	"strings"
+
+	platform "github.com/influxdata/influxdb/v2"
+)
+
+// schema (applied by this package's migrations, not shown here):
+//
+//   CREATE TABLE users (
+//       id                        BIGINT PRIMARY KEY,
+//       name                      TEXT NOT NULL,
+//       name_lower                TEXT UNIQUE NOT NULL,
+//       oauth_id                  TEXT,
+//       status                    TEXT NOT NULL,
+//       password_hash             TEXT,
+//       password_reset_required   BOOLEAN NOT NULL DEFAULT false,
+//       password_change_forced    BOOLEAN NOT NULL DEFAULT false,
+//       last_password_reset_at    TIMESTAMPTZ,
+//       roles                     TEXT[] NOT NULL DEFAULT '{}',
+//       last_login_ip             TEXT,
+//       last_login_at             TIMESTAMPTZ,
+//       last_login_attempts       INTEGER NOT NULL DEFAULT 0,
+//       locked_until              TIMESTAMPTZ,
+//       updated_at                TIMESTAMPTZ NOT NULL DEFAULT now()
+//   );
+//   -- name_lower carries the case-insensitive uniqueness constraint and
+//   -- doubles as the name -> id secondary index.
+
+const userColumns = `id, name, oauth_id, status, password_hash, password_reset_required,
+	password_change_forced, last_password_reset_at, roles, last_login_ip,
+	last_login_at, last_login_attempts, locked_until`
+
+// UserStorageBackend is a platform.UserStorageBackend backed by Postgres.
+type UserStorageBackend struct {
+	db *sql.DB
+}
+
+// NewUserStorageBackend returns a UserStorageBackend backed by db. The
+// users table (see the schema comment above) is expected to already
+// exist, created by this package's migrations.
+func NewUserStorageBackend(db *sql.DB) *UserStorageBackend {
+	return &UserStorageBackend{db: db}
+}
+
+func (s *UserStorageBackend) GetBy(key interface{}) (*platform.User, error) {
+	var row *sql.Row
+	switch k := key.(type) {
+	case platform.ID:
+		row = s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, k)
+	case string:
+		row = s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE name_lower = lower($1)`, k)
+	default:
+		return nil, &platform.Error{Code: platform.EInvalid, Msg: "GetBy key must be an ID or a name"}
+	}
+	return scanUser(row)
+}
+
+func (s *UserStorageBackend) Gets(filter platform.UserFilter, opt platform.FindOptions) ([]*platform.User, int, error) {
+	where := ` WHERE true`
+	var args []interface{}
+
+	if filter.ID != nil {
+		args = append(args, *filter.ID)
+		where += fmtArg(" AND id = $%d", len(args))
+	}
+	if filter.Name != nil {
+		args = append(args, *filter.Name)
+		where += fmtArg(" AND name_lower = lower($%d)", len(args))
+	}
+	if filter.Role != nil {
+		args = append(args, *filter.Role)
+		where += fmtArg(" AND $%d = ANY(roles)", len(args))
+	}
+	if filter.LastLoginBefore != nil {
+		args = append(args, *filter.LastLoginBefore)
+		where += fmtArg(" AND (last_login_at IS NULL OR last_login_at < $%d)", len(args))
+	}
+
+	// The total is counted separately from the LIMIT/OFFSET query below,
+	// since FindUsers reports the count of all matches, not just the page
+	// returned.
+	var total int
+	if err := s.db.QueryRow(`SELECT count(*) FROM users`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	q := `SELECT ` + userColumns + ` FROM users` + where + orderByClause(opt)
+	pageArgs := append([]interface{}{}, args...)
+	if opt.Limit > 0 {
+		pageArgs = append(pageArgs, opt.Limit)
+		q += fmtArg(" LIMIT $%d", len(pageArgs))
+	}
+	if opt.Offset > 0 {
+		pageArgs = append(pageArgs, opt.Offset)
+		q += fmtArg(" OFFSET $%d", len(pageArgs))
+	}
+
+	rows, err := s.db.Query(q, pageArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []*platform.User
+	for rows.Next() {
+		u, err := scanUserRows(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, u)
+	}
+	return users, total, rows.Err()
+}
+
+// orderByClause maps opt.SortBy to the column it names, defaulting to the
+// same case-insensitive name ordering the in-memory and Bolt backends use.
+func orderByClause(opt platform.FindOptions) string {
+	col := "name_lower"
+	switch opt.SortBy {
+	case "ID":
+		col = "id"
+	case "LastLoginAt":
+		col = "last_login_at"
+	}
+	if opt.Descending {
+		return " ORDER BY " + col + " DESC"
+	}
+	return " ORDER BY " + col
+}
+
+func (s *UserStorageBackend) Save(u *platform.User) error {
+	_, err := s.db.Exec(`
+		INSERT INTO users (id, name, name_lower, oauth_id, status, password_hash,
+			password_reset_required, password_change_forced, last_password_reset_at,
+			roles, updated_at)
+		VALUES ($1, $2, lower($2), $3, $4, $5, $6, $7, $8, $9, now())`,
+		u.ID, u.Name, u.OAuthID, string(u.Status), u.PasswordHash, u.PasswordResetRequired,
+		u.PasswordChangeForced, nullTime(u.LastPasswordResetAt), pqStringArray(u.Roles))
+	if isUniqueViolation(err) {
+		return &platform.Error{Code: platform.EConflict, Msg: "user with that name already exists"}
+	}
+	return err
+}
+
+// Update persists u's current state. fields is accepted for interface
+// compatibility with platform.UserStorageBackend; Postgres rewrites every
+// mapped column regardless since the row is small and this keeps the
+// implementation simple.
+func (s *UserStorageBackend) Update(u *platform.User, fields ...string) error {
+	_, err := s.db.Exec(`
+		UPDATE users SET
+			name = $2, name_lower = lower($2), oauth_id = $3, status = $4,
+			password_hash = $5, password_reset_required = $6, password_change_forced = $7,
+			last_password_reset_at = $8, roles = $9, last_login_ip = $10,
+			last_login_at = $11, last_login_attempts = $12, locked_until = $13,
+			updated_at = now()
+		WHERE id = $1`,
+		u.ID, u.Name, u.OAuthID, string(u.Status), u.PasswordHash, u.PasswordResetRequired,
+		u.PasswordChangeForced, nullTime(u.LastPasswordResetAt), pqStringArray(u.Roles),
+		u.LastLoginIP, nullTime(u.LastLoginAt), u.LastLoginAttempts, nullTimePtr(u.LockedUntil))
+	if isUniqueViolation(err) {
+		return &platform.Error{Code: platform.EConflict, Msg: "user with that name already exists"}
+	}
+	return err
+}
+
+func (s *UserStorageBackend) DeleteByID(id platform.ID) error {
+	res, err := s.db.Exec(`DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return &platform.Error{Code: platform.ENotFound, Msg: "user not found"}
+	}
+	return nil
+}
+
+func (s *UserStorageBackend) LastUpdate(id platform.ID) int64 {
+	var nanos int64
+	_ = s.db.QueryRow(`SELECT (extract(epoch from updated_at) * 1e9)::bigint FROM users WHERE id = $1`, id).Scan(&nanos)
+	return nanos
+}
+
+// isUniqueViolation reports whether err came from the name_lower unique
+// constraint. It matches on the error message rather than a specific
+// driver's error type so this package doesn't need to depend on one
+// particular Postgres driver.
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, hint := range []string{"unique constraint", "duplicate key", "SQLSTATE 23505"} {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,315 @@
+package influxdb
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// userService is the canonical UserService implementation. All of its
+// behavior is expressed in terms of a UserStorageBackend, so it works
+// unmodified against BoltDB, Postgres, or an in-memory store.
+type userService struct {
+	backend UserStorageBackend
+	hasher  PasswordHasher
+	idGen   IDGenerator
+	authz   *Authorizer
+	audit   UserAuditService
+}
+
+// UserServiceOption configures optional dependencies of NewUserService.
+type UserServiceOption func(*userService)
+
+// WithPasswordHasher overrides the default bcrypt PasswordHasher. Tests
+// typically inject a low-cost hasher so creating many users stays fast.
+func WithPasswordHasher(h PasswordHasher) UserServiceOption {
+	return func(s *userService) { s.hasher = h }
+}
+
+// WithIDGenerator overrides the default ID generator.
+func WithIDGenerator(g IDGenerator) UserServiceOption {
+	return func(s *userService) { s.idGen = g }
+}
+
+// WithAuthorizer enables RBAC checks on mutating calls. Without one,
+// CreateUser/UpdateUser/DeleteUser perform no permission check, which is
+// only appropriate for tests and single-user deployments.
+func WithAuthorizer(a *Authorizer) UserServiceOption {
+	return func(s *userService) { s.authz = a }
+}
+
+// WithUserAuditService records every RecordLogin call to audit. Without
+// one, login attempts still drive lockout but leave no queryable history.
+func WithUserAuditService(audit UserAuditService) UserServiceOption {
+	return func(s *userService) { s.audit = audit }
+}
+
+// NewUserService returns a UserService backed by back.
+func NewUserService(back UserStorageBackend, opts ...UserServiceOption) UserService {
+	s := &userService{
+		backend: back,
+		hasher:  NewBcryptHasher(DefaultPasswordHashCost),
+		idGen:   NewIDGenerator(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *userService) authorize(ctx context.Context, permission string) error {
+	if s.authz == nil {
+		return nil
+	}
+	return s.authz.Authorize(ctx, permission)
+}
+
+func (s *userService) FindUserByID(ctx context.Context, id ID) (*User, error) {
+	return s.backend.GetBy(id)
+}
+
+func (s *userService) FindUser(ctx context.Context, filter UserFilter) (*User, error) {
+	if filter.ID != nil {
+		return s.backend.GetBy(*filter.ID)
+	}
+	if filter.Name != nil {
+		return s.backend.GetBy(*filter.Name)
+	}
+	return nil, &Error{Code: EInvalid, Op: OpFindUser, Msg: "no filter parameters provided"}
+}
+
+func (s *userService) LookupUserID(ctx context.Context, name string) (ID, error) {
+	u, err := s.backend.GetBy(name)
+	if err != nil {
+		return ID(0), err
+	}
+	return u.ID, nil
+}
+
+func (s *userService) UserExists(ctx context.Context, name string) (bool, error) {
+	_, err := s.backend.GetBy(name)
+	if err != nil {
+		if errCode(err) == ENotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *userService) FindUsers(ctx context.Context, filter UserFilter, opt ...FindOptions) ([]*User, int, error) {
+	var findOpt FindOptions
+	if len(opt) > 0 {
+		findOpt = opt[0]
+	}
+
+	users, n, err := s.backend.Gets(filter, findOpt)
+	if err != nil {
+		return nil, 0, ErrInternalUserServiceError(OpFindUsers, err)
+	}
+
+	if p, ok := PrincipalFromContext(ctx); ok {
+		// Resolve read:users once: Authorizer.Permissions does a
+		// RoleService round trip per role, so re-checking it inside the
+		// loop below would cost O(users × roles) round trips instead of one.
+		canReadAll := s.authorize(ctx, PermissionReadUsers) == nil
+		if canReadAll {
+			return users, n, nil
+		}
+
+		visible := users[:0]
+		for _, u := range users {
+			if u.ID == p.UserID || canReadAll {
+				visible = append(visible, u)
+			}
+		}
+		return visible, len(visible), nil
+	}
+
+	return users, n, nil
+}
+
+func (s *userService) CreateUser(ctx context.Context, u *User) error {
+	if err := s.authorize(ctx, PermissionWriteUsers); err != nil {
+		return err
+	}
+	if err := u.Valid(); err != nil {
+		return err
+	}
+	if exists, err := s.UserExists(ctx, u.Name); err != nil {
+		return err
+	} else if exists {
+		return &Error{Code: EConflict, Op: OpCreateUser, Msg: "user with that name already exists"}
+	}
+
+	u.ID = s.idGen.ID()
+	if err := s.backend.Save(u); err != nil {
+		return ErrInternalUserServiceError(OpCreateUser, err)
+	}
+	return nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, id ID, upd UserUpdate) (*User, error) {
+	if err := s.authorize(ctx, PermissionWriteUsers); err != nil {
+		return nil, err
+	}
+	if err := upd.Valid(); err != nil {
+		return nil, err
+	}
+
+	u, err := s.backend.GetBy(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	if upd.Name != nil && !strings.EqualFold(*upd.Name, u.Name) {
+		if exists, err := s.UserExists(ctx, *upd.Name); err != nil {
+			return nil, err
+		} else if exists {
+			return nil, &Error{Code: EConflict, Op: OpUpdateUser, Msg: "user with that name already exists"}
+		}
+		u.Name = *upd.Name
+		fields = append(fields, "Name")
+	}
+	if upd.Status != nil {
+		u.Status = *upd.Status
+		fields = append(fields, "Status")
+	}
+
+	if err := s.backend.Update(u, fields...); err != nil {
+		return nil, ErrInternalUserServiceError(OpUpdateUser, err)
+	}
+	return u, nil
+}
+
+func (s *userService) DeleteUser(ctx context.Context, id ID) error {
+	if err := s.authorize(ctx, PermissionWriteUsers); err != nil {
+		return err
+	}
+	if err := s.backend.DeleteByID(id); err != nil {
+		return ErrInternalUserServiceError(OpDeleteUser, err)
+	}
+	return nil
+}
+
+func (s *userService) SetPassword(ctx context.Context, id ID, password string) error {
+	u, err := s.backend.GetBy(id)
+	if err != nil {
+		return err
+	}
+
+	if err := checkPasswordValid(s.hasher, u.PasswordHash, password); err != nil {
+		return err
+	}
+
+	hash, err := s.hasher.HashPassword(password)
+	if err != nil {
+		return ErrInternalUserServiceError(OpSetPassword, err)
+	}
+
+	u.PasswordHash = hash
+	u.PasswordResetRequired = false
+	u.LastPasswordResetAt = now()
+
+	if err := s.backend.Update(u, "PasswordHash", "PasswordResetRequired", "LastPasswordResetAt"); err != nil {
+		return ErrInternalUserServiceError(OpSetPassword, err)
+	}
+	return nil
+}
+
+func (s *userService) ComparePassword(ctx context.Context, id ID, password string) error {
+	u, err := s.backend.GetBy(id)
+	if err != nil {
+		return ErrPasswordMismatch
+	}
+	return s.hasher.ComparePassword(u.PasswordHash, password)
+}
+
+func (s *userService) Authenticate(ctx context.Context, name string, password string, ip string) (*User, error) {
+	u, err := s.backend.GetBy(name)
+	if err != nil {
+		return nil, ErrPasswordMismatch
+	}
+
+	if u.LockedUntil != nil && now().Before(*u.LockedUntil) {
+		// Still record the attempt: it's evidence for the audit log and
+		// it extends the lockout if an attacker is actively retrying,
+		// instead of silently letting the clock run out unobserved.
+		_ = s.RecordLogin(ctx, u.ID, ip, false)
+		return nil, ErrUserLocked
+	}
+
+	if err := s.hasher.ComparePassword(u.PasswordHash, password); err != nil {
+		_ = s.RecordLogin(ctx, u.ID, ip, false)
+		return nil, ErrPasswordMismatch
+	}
+
+	if err := s.RecordLogin(ctx, u.ID, ip, true); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *userService) RecordLogin(ctx context.Context, id ID, ip string, success bool) error {
+	u, err := s.backend.GetBy(id)
+	if err != nil {
+		return err
+	}
+
+	if success {
+		u.LastLoginAttempts = 0
+		u.LockedUntil = nil
+	} else {
+		// LastLoginAttempts counts a streak of failures, each within
+		// LoginAttemptWindow of the attempt before it, not all-time: if the
+		// previous failure fell
+		// outside the window, this is the start of a new streak. Without
+		// this reset, an account that ever crosses the threshold would
+		// stay at >=LoginAttemptThreshold forever, so every later attempt
+		// re-locks it for another LoginLockoutDuration even long after
+		// the attacker (or the legitimate user) stopped.
+		if u.LastLoginAttempts > 0 && now().Sub(u.LastLoginAt) > LoginAttemptWindow {
+			u.LastLoginAttempts = 0
+		}
+		u.LastLoginAttempts++
+		if u.LastLoginAttempts >= LoginAttemptThreshold {
+			lockedUntil := now().Add(LoginLockoutDuration)
+			u.LockedUntil = &lockedUntil
+		}
+	}
+
+	u.LastLoginIP = ip
+	u.LastLoginAt = now()
+
+	if err := s.backend.Update(u, "LastLoginIP", "LastLoginAt", "LastLoginAttempts", "LockedUntil"); err != nil {
+		return err
+	}
+
+	if s.audit != nil {
+		evt := LoginEvent{UserID: id, Time: u.LastLoginAt, IP: ip, Succeeded: success}
+		if err := s.audit.RecordLoginEvent(ctx, evt); err != nil {
+			return ErrInternalUserServiceError(OpRecordLogin, err)
+		}
+	}
+
+	return nil
+}
+
+// LastUpdate returns the last time the user with the given ID was
+// written to the backend, letting callers detect staleness (e.g. for an
+// HTTP ETag) without re-reading the full record.
+func (s *userService) LastUpdate(id ID) int64 {
+	return s.backend.LastUpdate(id)
+}
+
+// now is a seam so tests can fake the clock; production code always uses
+// the real time.
+var now = time.Now
+
+func errCode(err error) string {
+	if e, ok := err.(*Error); ok {
+		return e.Code
+	}
+	return EInternal
+}
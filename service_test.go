@@ -0,0 +1,216 @@
+package influxdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestUserService() UserService {
+	return NewUserService(
+		NewInmemUserStorageBackend(),
+		WithPasswordHasher(NewBcryptHasher(bcrypt.MinCost)),
+	)
+}
+
+func newTestUserServiceWithAudit(audit UserAuditService) UserService {
+	return NewUserService(
+		NewInmemUserStorageBackend(),
+		WithPasswordHasher(NewBcryptHasher(bcrypt.MinCost)),
+		WithUserAuditService(audit),
+	)
+}
+
+func TestUserService_CreateAndFind(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestUserService()
+
+	u := &User{Name: "marge", Status: "active"}
+	if err := svc.CreateUser(ctx, u); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+	if u.ID == 0 {
+		t.Fatal("CreateUser() did not set an ID")
+	}
+
+	got, err := svc.FindUserByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("FindUserByID() = %v", err)
+	}
+	if got.Name != "marge" {
+		t.Fatalf("FindUserByID() got name %q, want %q", got.Name, "marge")
+	}
+}
+
+func TestUserService_CreateUser_DuplicateNameConflicts(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestUserService()
+
+	if err := svc.CreateUser(ctx, &User{Name: "marge", Status: "active"}); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+
+	err := svc.CreateUser(ctx, &User{Name: "Marge", Status: "active"})
+	if err == nil {
+		t.Fatal("CreateUser() with a duplicate name = nil, want EConflict")
+	}
+}
+
+func TestUserService_SetPasswordAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestUserService()
+
+	u := &User{Name: "marge", Status: "active"}
+	if err := svc.CreateUser(ctx, u); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+	if err := svc.SetPassword(ctx, u.ID, "correcthorsebattery"); err != nil {
+		t.Fatalf("SetPassword() = %v", err)
+	}
+
+	if _, err := svc.Authenticate(ctx, "marge", "wrongpassword", "203.0.113.1"); err == nil {
+		t.Fatal("Authenticate() with wrong password = nil, want error")
+	}
+
+	got, err := svc.Authenticate(ctx, "marge", "correcthorsebattery", "203.0.113.1")
+	if err != nil {
+		t.Fatalf("Authenticate() = %v", err)
+	}
+	if got.ID != u.ID {
+		t.Fatalf("Authenticate() returned user %v, want %v", got.ID, u.ID)
+	}
+	if got.LastLoginIP != "203.0.113.1" {
+		t.Fatalf("Authenticate() LastLoginIP = %q, want %q", got.LastLoginIP, "203.0.113.1")
+	}
+}
+
+func TestUserService_AuthenticateLocksAfterRepeatedFailures(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestUserService()
+
+	u := &User{Name: "marge", Status: "active"}
+	if err := svc.CreateUser(ctx, u); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+	if err := svc.SetPassword(ctx, u.ID, "correcthorsebattery"); err != nil {
+		t.Fatalf("SetPassword() = %v", err)
+	}
+
+	for i := 0; i < LoginAttemptThreshold; i++ {
+		if _, err := svc.Authenticate(ctx, "marge", "wrongpassword", "203.0.113.1"); err == nil {
+			t.Fatal("Authenticate() with wrong password = nil, want error")
+		}
+	}
+
+	if _, err := svc.Authenticate(ctx, "marge", "correcthorsebattery", "203.0.113.1"); err != ErrUserLocked {
+		t.Fatalf("Authenticate() after lockout = %v, want ErrUserLocked", err)
+	}
+}
+
+func TestUserService_FailedAttemptsResetOutsideWindow(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestUserService()
+
+	u := &User{Name: "marge", Status: "active"}
+	if err := svc.CreateUser(ctx, u); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+	if err := svc.SetPassword(ctx, u.ID, "correcthorsebattery"); err != nil {
+		t.Fatalf("SetPassword() = %v", err)
+	}
+
+	realNow := now
+	defer func() { now = realNow }()
+	t0 := realNow()
+	now = func() time.Time { return t0 }
+
+	for i := 0; i < LoginAttemptThreshold; i++ {
+		if _, err := svc.Authenticate(ctx, "marge", "wrongpassword", "203.0.113.1"); err == nil {
+			t.Fatal("Authenticate() with wrong password = nil, want error")
+		}
+	}
+
+	// Advance past both the lockout duration and the attempt window: a
+	// fresh failure should start a new streak, not re-lock immediately.
+	now = func() time.Time { return t0.Add(LoginAttemptWindow + time.Minute) }
+
+	if _, err := svc.Authenticate(ctx, "marge", "wrongpassword", "203.0.113.1"); err != ErrPasswordMismatch {
+		t.Fatalf("Authenticate() after the window elapsed = %v, want ErrPasswordMismatch (not locked)", err)
+	}
+
+	got, err := svc.Authenticate(ctx, "marge", "correcthorsebattery", "203.0.113.1")
+	if err != nil {
+		t.Fatalf("Authenticate() with the correct password after the window elapsed = %v, want success", err)
+	}
+	if got.ID != u.ID {
+		t.Fatalf("Authenticate() returned user %v, want %v", got.ID, u.ID)
+	}
+}
+
+func TestUserService_RecordLoginWritesAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	audit := NewInmemUserAuditService()
+	svc := newTestUserServiceWithAudit(audit)
+
+	u := &User{Name: "marge", Status: "active"}
+	if err := svc.CreateUser(ctx, u); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+	if err := svc.SetPassword(ctx, u.ID, "correcthorsebattery"); err != nil {
+		t.Fatalf("SetPassword() = %v", err)
+	}
+
+	if _, err := svc.Authenticate(ctx, "marge", "wrongpassword", "203.0.113.1"); err == nil {
+		t.Fatal("Authenticate() with wrong password = nil, want error")
+	}
+	if _, err := svc.Authenticate(ctx, "marge", "correcthorsebattery", "203.0.113.1"); err != nil {
+		t.Fatalf("Authenticate() = %v", err)
+	}
+
+	events, err := audit.FindLoginEvents(ctx, u.ID, TimeRange{Start: time.Time{}, Stop: now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("FindLoginEvents() = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("FindLoginEvents() returned %d events, want 2", len(events))
+	}
+	if events[0].Succeeded || !events[1].Succeeded {
+		t.Fatalf("FindLoginEvents() = %+v, want [failure, success]", events)
+	}
+}
+
+func TestUserService_AuthenticateRecordsAttemptsWhileLocked(t *testing.T) {
+	ctx := context.Background()
+	audit := NewInmemUserAuditService()
+	svc := newTestUserServiceWithAudit(audit)
+
+	u := &User{Name: "marge", Status: "active"}
+	if err := svc.CreateUser(ctx, u); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+	if err := svc.SetPassword(ctx, u.ID, "correcthorsebattery"); err != nil {
+		t.Fatalf("SetPassword() = %v", err)
+	}
+
+	for i := 0; i < LoginAttemptThreshold; i++ {
+		if _, err := svc.Authenticate(ctx, "marge", "wrongpassword", "203.0.113.1"); err == nil {
+			t.Fatal("Authenticate() with wrong password = nil, want error")
+		}
+	}
+
+	// The account is now locked; a further attempt should still be
+	// recorded, not silently dropped.
+	if _, err := svc.Authenticate(ctx, "marge", "correcthorsebattery", "203.0.113.1"); err != ErrUserLocked {
+		t.Fatalf("Authenticate() while locked = %v, want ErrUserLocked", err)
+	}
+
+	events, err := audit.FindLoginEvents(ctx, u.ID, TimeRange{Start: time.Time{}, Stop: now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("FindLoginEvents() = %v", err)
+	}
+	if len(events) != LoginAttemptThreshold+1 {
+		t.Fatalf("FindLoginEvents() returned %d events, want %d", len(events), LoginAttemptThreshold+1)
+	}
+}
@@ -0,0 +1,37 @@
+package influxdb
+
+// UserStorageBackend is the persistence contract NewUserService is built
+// on. It decouples the UserService implementation from any one storage
+// engine: bolt.NewUserStorageBackend and postgres.NewUserStorageBackend
+// (in their respective packages) both satisfy it against a real database,
+// while NewInmemUserStorageBackend in this package satisfies it in
+// memory for tests.
+type UserStorageBackend interface {
+	// GetBy returns the user matching key, which may be an ID or a
+	// string name (matched case-insensitively). It returns ENotFound if
+	// no user matches.
+	GetBy(key interface{}) (*User, error)
+
+	// Gets returns the users matching filter and the total count of
+	// matches, before opt's pagination is applied.
+	Gets(filter UserFilter, opt FindOptions) ([]*User, int, error)
+
+	// Save persists a new user. It returns EConflict if a user with the
+	// same name (case-insensitively) already exists.
+	Save(u *User) error
+
+	// Update persists changes to an existing user. fields names the
+	// fields the caller changed; an implementation may write the whole
+	// record regardless, as the in-memory, Bolt and Postgres backends do.
+	Update(u *User, fields ...string) error
+
+	// DeleteByID removes a user. It returns ENotFound if no user has
+	// that ID.
+	DeleteByID(id ID) error
+
+	// LastUpdate returns the unix nanosecond timestamp the user with the
+	// given ID was last written, or zero if the user does not exist.
+	// Higher layers (the caching decorator, HTTP ETags) use this to
+	// detect staleness without re-reading the full record.
+	LastUpdate(id ID) int64
+}
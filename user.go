@@ -3,6 +3,7 @@ package influxdb
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 // UserStatus indicates whether a user is active or inactive
@@ -23,6 +24,43 @@ type User struct {
 	Name    string `json:"name"`
 	OAuthID string `json:"oauthID,omitempty"`
 	Status  Status `json:"status"`
+
+	// PasswordHash is the bcrypt hash of the user's password. It is never
+	// serialized back to clients.
+	PasswordHash string `json:"-"`
+
+	// PasswordResetRequired indicates an operator has forced this user to
+	// pick a new password before their next successful authentication.
+	PasswordResetRequired bool `json:"passwordResetRequired,omitempty"`
+
+	// PasswordChangeForced records that the last password change was not
+	// initiated by the user themselves (e.g. an admin- or policy-driven reset).
+	PasswordChangeForced bool `json:"passwordChangeForced,omitempty"`
+
+	// LastPasswordResetAt is the time of the most recent password change.
+	LastPasswordResetAt time.Time `json:"lastPasswordResetAt,omitempty"`
+
+	// Roles are the names of the roles granted to this user. Effective
+	// permissions are resolved from these via the RoleService.
+	Roles []string `json:"roles,omitempty"`
+
+	// LastLoginIP is the source IP of the most recent login attempt,
+	// successful or not.
+	LastLoginIP string `json:"lastLoginIP,omitempty"`
+
+	// LastLoginAt is the time of the most recent login attempt.
+	LastLoginAt time.Time `json:"lastLoginAt,omitempty"`
+
+	// LastLoginAttempts counts consecutive failed login attempts since the
+	// last success, starting over when a failure comes more than
+	// LoginAttemptWindow after the previous attempt. It resets to zero on a
+	// successful Authenticate call.
+	LastLoginAttempts int `json:"lastLoginAttempts,omitempty"`
+
+	// LockedUntil is set once LastLoginAttempts crosses the configured
+	// failure threshold, and causes Authenticate to return ELocked until
+	// it elapses.
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"`
 }
 
 // Valid validates user
@@ -39,6 +77,10 @@ const (
 	OpPutUser      = "PutUser"
 	OpUpdateUser   = "UpdateUser"
 	OpDeleteUser   = "DeleteUser"
+
+	OpSetPassword     = "SetPassword"
+	OpComparePassword = "ComparePassword"
+	OpRecordLogin     = "RecordLogin"
 )
 
 // UserService represents a service for managing user data.
@@ -50,19 +92,70 @@ type UserService interface {
 	// Returns the first user that matches filter.
 	FindUser(ctx context.Context, filter UserFilter) (*User, error)
 
+	// LookupUserID returns the ID of the user with the given name, doing a
+	// case-insensitive match. It returns ENotFound if no user has that name.
+	LookupUserID(ctx context.Context, name string) (ID, error)
+
+	// UserExists reports whether a user with the given name already
+	// exists, doing a case-insensitive match. It exists so callers (e.g.
+	// signup forms, admin UIs) can check name availability without
+	// pulling the full record.
+	UserExists(ctx context.Context, name string) (bool, error)
+
 	// Returns a list of users that match filter and the total count of matching users.
-	// Additional options provide pagination & sorting.
+	// Additional options provide pagination & sorting. Users the caller's
+	// principal is not authorized to see are omitted from both the results
+	// and the count.
 	FindUsers(ctx context.Context, filter UserFilter, opt ...FindOptions) ([]*User, int, error)
 
 	// Creates a new user and sets u.ID with the new identifier.
+	//
+	// The caller's principal (see PrincipalFromContext) must hold the
+	// write:users permission, or CreateUser returns EForbidden. u.Name
+	// must be unique, case-insensitively, among existing users, or
+	// CreateUser returns EConflict.
 	CreateUser(ctx context.Context, u *User) error
 
 	// Updates a single user with changeset.
 	// Returns the new user state after update.
+	//
+	// The caller's principal must hold the write:users permission, or
+	// UpdateUser returns EForbidden. If upd.Name collides, case-
+	// insensitively, with another existing user, UpdateUser returns
+	// EConflict.
 	UpdateUser(ctx context.Context, id ID, upd UserUpdate) (*User, error)
 
 	// Removes a user by ID.
+	//
+	// The caller's principal must hold the write:users permission, or
+	// DeleteUser returns EForbidden.
 	DeleteUser(ctx context.Context, id ID) error
+
+	// SetPassword sets the password of a user by ID, replacing any existing
+	// PasswordHash. It returns EInvalid if the password does not meet the
+	// minimum requirements enforced by checkPasswordValid.
+	SetPassword(ctx context.Context, id ID, password string) error
+
+	// ComparePassword compares password against the stored hash for id,
+	// returning ErrPasswordMismatch if they do not match. It does not
+	// distinguish between a missing user and a wrong password.
+	ComparePassword(ctx context.Context, id ID, password string) error
+
+	// Authenticate finds the user by name and compares password against
+	// their stored hash, returning the user on success. It returns
+	// ErrPasswordMismatch for both an unknown user and an incorrect
+	// password so callers cannot probe for valid user names, and returns
+	// ELocked if the user's LockedUntil has not yet elapsed. Every
+	// attempt on an existing user, including ones rejected by ELocked, is
+	// recorded via RecordLogin using ip as the source address; attempts
+	// for an unknown name are not recorded.
+	Authenticate(ctx context.Context, name string, password string, ip string) (*User, error)
+
+	// RecordLogin records the outcome of a login attempt for audit and
+	// lockout purposes: it updates LastLoginIP/LastLoginAt, and either
+	// resets LastLoginAttempts on success or increments it on failure,
+	// setting LockedUntil once the failure threshold is crossed.
+	RecordLogin(ctx context.Context, id ID, ip string, success bool) error
 }
 
 // UserUpdate represents updates to a user.
@@ -85,6 +178,12 @@ func (uu UserUpdate) Valid() error {
 type UserFilter struct {
 	ID   *ID
 	Name *string
+	Role *string
+
+	// LastLoginBefore restricts results to users who have not logged in
+	// since this time (or who have never logged in), letting operators
+	// find dormant accounts to disable in bulk.
+	LastLoginBefore *time.Time
 }
 
 func ErrInternalUserServiceError(op string, err error) *Error {
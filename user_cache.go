@@ -0,0 +1,337 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CacheOptions configures NewCachingUserService.
+type CacheOptions struct {
+	// TTL is how long a cached entry remains valid before it is treated as
+	// a miss. Defaults to 5 minutes.
+	TTL time.Duration
+
+	// FullRefreshInterval is how often the cache proactively repopulates
+	// itself from the inner UserService in the background, bounding
+	// staleness even for keys that are never re-requested. A zero value
+	// (the default) disables background refresh, leaving entries to expire
+	// on their own TTL.
+	FullRefreshInterval time.Duration
+}
+
+func (o CacheOptions) withDefaults() CacheOptions {
+	if o.TTL <= 0 {
+		o.TTL = 5 * time.Minute
+	}
+	return o
+}
+
+var (
+	userCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "influxdb",
+		Subsystem: "user_cache",
+		Name:      "hits_total",
+		Help:      "Number of UserService cache lookups served from cache.",
+	})
+	userCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "influxdb",
+		Subsystem: "user_cache",
+		Name:      "misses_total",
+		Help:      "Number of UserService cache lookups not found in cache.",
+	})
+	userCacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "influxdb",
+		Subsystem: "user_cache",
+		Name:      "evictions_total",
+		Help:      "Number of UserService cache entries evicted by Purge, including the background full refresh. Entries that outlive their TTL are replaced on the next read and are not counted.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(userCacheHits, userCacheMisses, userCacheEvictions)
+}
+
+type userCacheEntry struct {
+	user      *User
+	users     []*User
+	count     int
+	expiresAt time.Time
+}
+
+func (e *userCacheEntry) expired(now time.Time) bool {
+	return now.After(e.expiresAt)
+}
+
+// cachingUserService decorates a UserService with an in-memory, TTL'd
+// cache of FindUserByID/FindUser/FindUsers results. It is modeled on the
+// read-through caches used elsewhere in front of slow backend queries: a
+// map guarded by sync.RWMutex, entries expired on read, and a background
+// goroutine that periodically drops the whole cache so long-TTL entries
+// can't drift arbitrarily far from the backend.
+type cachingUserService struct {
+	inner UserService
+	opts  CacheOptions
+
+	mu      sync.RWMutex
+	byID    map[ID]*userCacheEntry
+	byName  map[string]*userCacheEntry
+	byQuery map[string]*userCacheEntry
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+// NewCachingUserService wraps inner with an in-memory cache of read
+// results. Mutating calls are passed straight through to inner and
+// invalidate any cached entries they affect; Purge lets callers outside
+// this process (e.g. a peer in a clustered deployment) invalidate a user
+// that may be cached here without access to this instance's state.
+//
+// If opts.FullRefreshInterval is positive, NewCachingUserService starts a
+// background goroutine to drive it; callers that do so must call Close
+// when the returned *cachingUserService is no longer needed, or that
+// goroutine leaks for the lifetime of the process.
+func NewCachingUserService(inner UserService, opts CacheOptions) *cachingUserService {
+	c := &cachingUserService{
+		inner:   inner,
+		opts:    opts.withDefaults(),
+		byID:    make(map[ID]*userCacheEntry),
+		byName:  make(map[string]*userCacheEntry),
+		byQuery: make(map[string]*userCacheEntry),
+		closeCh: make(chan struct{}),
+	}
+	if c.opts.FullRefreshInterval > 0 {
+		go c.refreshLoop()
+	}
+	return c
+}
+
+// Close stops the background refresh goroutine. It is safe to call more
+// than once.
+func (c *cachingUserService) Close() {
+	c.closeOnce.Do(func() { close(c.closeCh) })
+}
+
+func (c *cachingUserService) refreshLoop() {
+	t := time.NewTicker(c.opts.FullRefreshInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.closeCh:
+			return
+		case <-t.C:
+			c.Purge(ID(0))
+		}
+	}
+}
+
+// Purge drops every cached entry for id, as well as the whole
+// FindUsers query cache, since a single user's change can affect
+// the result of any filtered listing. Passing the zero ID purges
+// everything, which is how the background refresh loop repopulates from
+// the backend on the next read.
+func (c *cachingUserService) Purge(id ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if id == ID(0) {
+		userCacheEvictions.Add(float64(len(c.byID) + len(c.byName) + len(c.byQuery)))
+		c.byID = make(map[ID]*userCacheEntry)
+		c.byName = make(map[string]*userCacheEntry)
+		c.byQuery = make(map[string]*userCacheEntry)
+		return
+	}
+
+	if e, ok := c.byID[id]; ok {
+		delete(c.byID, id)
+		if e.user != nil {
+			delete(c.byName, e.user.Name)
+		}
+		userCacheEvictions.Inc()
+	}
+	// Any cached FindUsers listing could contain the purged user.
+	for k := range c.byQuery {
+		delete(c.byQuery, k)
+		userCacheEvictions.Inc()
+	}
+}
+
+func (c *cachingUserService) FindUserByID(ctx context.Context, id ID) (*User, error) {
+	c.mu.RLock()
+	e, ok := c.byID[id]
+	c.mu.RUnlock()
+	if ok && !e.expired(time.Now()) {
+		userCacheHits.Inc()
+		return e.user, nil
+	}
+	userCacheMisses.Inc()
+
+	u, err := c.inner.FindUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.storeByID(u)
+	return u, nil
+}
+
+func (c *cachingUserService) FindUser(ctx context.Context, filter UserFilter) (*User, error) {
+	if filter.Name != nil {
+		c.mu.RLock()
+		e, ok := c.byName[*filter.Name]
+		c.mu.RUnlock()
+		if ok && !e.expired(time.Now()) {
+			userCacheHits.Inc()
+			return e.user, nil
+		}
+	}
+	userCacheMisses.Inc()
+
+	u, err := c.inner.FindUser(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	c.storeByID(u)
+	return u, nil
+}
+
+// LookupUserID is passed straight through to inner: name→ID lookups are
+// backed by the storage layer's own secondary index, so caching them here
+// would only risk serving a stale ID past a rename.
+func (c *cachingUserService) LookupUserID(ctx context.Context, name string) (ID, error) {
+	return c.inner.LookupUserID(ctx, name)
+}
+
+// UserExists is passed straight through to inner for the same reason as
+// LookupUserID.
+func (c *cachingUserService) UserExists(ctx context.Context, name string) (bool, error) {
+	return c.inner.UserExists(ctx, name)
+}
+
+func (c *cachingUserService) FindUsers(ctx context.Context, filter UserFilter, opt ...FindOptions) ([]*User, int, error) {
+	// The result of FindUsers is filtered down to what the caller's
+	// principal may see (see request #2), so the cache key must include
+	// the principal: otherwise a privileged caller's unfiltered listing
+	// gets served back to a later, unprivileged caller with the same
+	// filter.
+	key := principalCacheKey(ctx) + "&" + userFilterCacheKey(filter, opt...)
+
+	c.mu.RLock()
+	e, ok := c.byQuery[key]
+	c.mu.RUnlock()
+	if ok && !e.expired(time.Now()) {
+		userCacheHits.Inc()
+		return e.users, e.count, nil
+	}
+	userCacheMisses.Inc()
+
+	users, n, err := c.inner.FindUsers(ctx, filter, opt...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.mu.Lock()
+	c.byQuery[key] = &userCacheEntry{users: users, count: n, expiresAt: time.Now().Add(c.opts.TTL)}
+	c.mu.Unlock()
+
+	return users, n, nil
+}
+
+func (c *cachingUserService) storeByID(u *User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &userCacheEntry{user: u, expiresAt: time.Now().Add(c.opts.TTL)}
+	c.byID[u.ID] = e
+	c.byName[u.Name] = e
+}
+
+// principalCacheKey identifies the caller for cache-key purposes: it
+// reflects exactly what FindUsers' visibility filtering depends on
+// (request #2), namely the principal's own ID and role set, or the
+// absence of a principal entirely.
+func principalCacheKey(ctx context.Context) string {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return "anon"
+	}
+	return fmt.Sprintf("user=%s&roles=%v", p.UserID.String(), p.Roles)
+}
+
+func userFilterCacheKey(filter UserFilter, opt ...FindOptions) string {
+	var id, name, role, lastLoginBefore string
+	if filter.ID != nil {
+		id = filter.ID.String()
+	}
+	if filter.Name != nil {
+		name = *filter.Name
+	}
+	if filter.Role != nil {
+		role = *filter.Role
+	}
+	if filter.LastLoginBefore != nil {
+		lastLoginBefore = filter.LastLoginBefore.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("id=%s&name=%s&role=%s&lastLoginBefore=%s&opt=%v", id, name, role, lastLoginBefore, opt)
+}
+
+func (c *cachingUserService) CreateUser(ctx context.Context, u *User) error {
+	if err := c.inner.CreateUser(ctx, u); err != nil {
+		return err
+	}
+	c.Purge(u.ID)
+	return nil
+}
+
+func (c *cachingUserService) UpdateUser(ctx context.Context, id ID, upd UserUpdate) (*User, error) {
+	u, err := c.inner.UpdateUser(ctx, id, upd)
+	if err != nil {
+		return nil, err
+	}
+	c.Purge(id)
+	return u, nil
+}
+
+func (c *cachingUserService) DeleteUser(ctx context.Context, id ID) error {
+	if err := c.inner.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+	c.Purge(id)
+	return nil
+}
+
+func (c *cachingUserService) SetPassword(ctx context.Context, id ID, password string) error {
+	if err := c.inner.SetPassword(ctx, id, password); err != nil {
+		return err
+	}
+	c.Purge(id)
+	return nil
+}
+
+func (c *cachingUserService) ComparePassword(ctx context.Context, id ID, password string) error {
+	return c.inner.ComparePassword(ctx, id, password)
+}
+
+func (c *cachingUserService) Authenticate(ctx context.Context, name string, password string, ip string) (*User, error) {
+	u, err := c.inner.Authenticate(ctx, name, password, ip)
+	// inner records the attempt, changing the user's login fields whether
+	// or not it succeeds.
+	if err == nil {
+		c.Purge(u.ID)
+	} else if id, lerr := c.inner.LookupUserID(ctx, name); lerr == nil {
+		c.Purge(id)
+	}
+	return u, err
+}
+
+// RecordLogin invalidates the cached entry for id, since it mutates
+// LastLoginAt/LastLoginAttempts/LockedUntil on the underlying user.
+func (c *cachingUserService) RecordLogin(ctx context.Context, id ID, ip string, success bool) error {
+	if err := c.inner.RecordLogin(ctx, id, ip, success); err != nil {
+		return err
+	}
+	c.Purge(id)
+	return nil
+}